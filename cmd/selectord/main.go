@@ -1,11 +1,26 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"math/big"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/n8maninger/sia-host-selector/internal/benchmark"
+	"github.com/n8maninger/sia-host-selector/internal/blocklist"
+	"github.com/n8maninger/sia-host-selector/internal/config"
+	"github.com/n8maninger/sia-host-selector/internal/diversity"
+	"github.com/n8maninger/sia-host-selector/internal/metrics"
+	"github.com/n8maninger/sia-host-selector/internal/pricetracker"
+	"github.com/n8maninger/sia-host-selector/internal/retention"
+	"github.com/n8maninger/sia-host-selector/internal/scoring"
 	"github.com/rodaine/table"
 	"github.com/shopspring/decimal"
 	"github.com/siacentral/apisdkgo"
@@ -21,31 +36,102 @@ var (
 )
 
 var (
-	// minimum of 50 hosts + a few extra for churn, will throw an error if not
-	// enough hosts are available
-	minHosts = 100
-	// $10 USD/TB
-	maxDownloadPrice = decimal.NewFromFloat(10)
-	// $1.00 USD/TB
-	maxUploadPrice = decimal.NewFromFloat(1)
-	// $2.00 USD/TB/mo
-	maxStorePrice = decimal.NewFromFloat(2)
-	// at least a month old (30 days * 144 blocks)
-	minAge uint64 = 30 * 144
-	// 85% as measured by Sia Central
-	minUptime float64 = 80
-	// 5Mbps as measured by Sia Central
-	//
-	// note: I leave this relatively low since not every host has good peering
-	// to the central benchmark server
-	minDownloadSpeed uint64 = 5e6
-	// 1Mbps as measured by Sia Central
-	//
-	// note: I leave this relatively low since not every host has good peering
-	// to the central benchmark server
-	minUploadSpeed uint64 = 1e6
+	configPath string
+
+	cfgMu sync.RWMutex
+	cfg   config.Config
+
+	priceHistoryDB   string
+	priceHistoryDays int
+	pricePercentile  float64
+
+	priceTracker *pricetracker.Tracker
+
+	dryRun bool
+
+	benchmarkConcurrency int
+	benchmarkTimeout     time.Duration
+
+	hostBenchmarker *benchmark.Benchmarker
+
+	geoipDB     string
+	geoResolver diversity.Resolver
+
+	tty         bool
+	metricsAddr string
+
+	selectorMetrics *metrics.Metrics
+
+	blocklistFile      string
+	stickyTolerance    float64
+	whitelistStateFile string
+
+	// benchmarkFailures tracks consecutive local-benchmark failures per
+	// host across cycles, so a host that keeps failing gets blocklisted
+	// outright instead of just excluded from the cycle that measured it.
+	benchmarkFailures = blocklist.NewFailureTracker()
+
+	// logger emits structured JSON, one line per host plus one summary
+	// line per update cycle, so operators can pipe into Loki/Elasticsearch
+	// and alert on drift. In --tty mode the human-readable tables below
+	// are printed instead and logger is used only for operational
+	// messages (startup, reloads, errors).
+	logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 )
 
+// benchmarkCacheTTL controls how long a host's local benchmark result is
+// reused across update cycles before it's re-measured.
+const benchmarkCacheTTL = 6 * time.Hour
+
+// blocklistFailureCycles is the number of consecutive cycles a host must
+// fail the local benchmark before it's treated as blocklisted and
+// excluded from every candidate set, not only from the cycles in which
+// its benchmark result is a failure.
+const blocklistFailureCycles = 3
+
+// loadConfig reads the config from configPath, falling back to
+// config.Defaults() when configPath is empty, and swaps it into cfg.
+func loadConfig() error {
+	if len(configPath) == 0 {
+		cfgMu.Lock()
+		cfg = config.Defaults()
+		cfgMu.Unlock()
+		return nil
+	}
+
+	c, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	cfgMu.Lock()
+	cfg = c
+	cfgMu.Unlock()
+	return nil
+}
+
+// currentConfig returns the active config, safe for concurrent use with
+// the SIGHUP reload handler.
+func currentConfig() config.Config {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
+	return cfg
+}
+
+// watchReloadSignal reloads the config whenever the process receives
+// SIGHUP, so operators can retune the selector without restarting it.
+func watchReloadSignal() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	for range sighup {
+		logger.Info("SIGHUP received, reloading config")
+		if err := loadConfig(); err != nil {
+			logger.Warn("unable to reload config", "error", err)
+		}
+	}
+}
+
 func formatBpsString(speed decimal.Decimal) string {
 	const units = "KMGTPE"
 	var factor = decimal.New(1000, 0)
@@ -66,7 +152,69 @@ func formatAge(d time.Duration) string {
 	return fmt.Sprintf("%0.2f w", d.Hours()/24/7)
 }
 
+// priceThresholds derives the max storage/upload/download prices (in
+// hastings) used to filter hosts. When the price tracker has enough
+// trailing history it uses the configured percentile of the real market
+// so the whitelist tracks movement instead of a fixed USD ceiling; with
+// no history yet (a cold start) it falls back to converting cfg's USD
+// ceilings at the current SC/USD rate.
+func priceThresholds(cfg config.Config, scUsdRate float64, maxDownloadPriceUSD decimal.Decimal) (maxStorePriceSC, maxUpPriceSC, maxDownPriceSC types.Currency, err error) {
+	if priceTracker != nil {
+		pct, perr := priceTracker.Percentile(time.Duration(priceHistoryDays)*24*time.Hour, pricePercentile)
+		if perr != nil {
+			return types.Currency{}, types.Currency{}, types.Currency{}, fmt.Errorf("unable to compute price percentile: %w", perr)
+		}
+		if pct.NumSamples > 0 {
+			maxDownPriceSC := pct.DownloadPrice
+			if cfg.MaxDownloadPrice.GreaterThan(decimal.Zero) {
+				// maxDownloadPriceUSD is cfg.MaxDownloadPrice itself on
+				// the normal path, and cfg.MaxDownloadPrice times the
+				// migration surcharge on the relaxed retry; apply that
+				// same ratio to the percentile-derived price so the
+				// surcharge still relaxes the cap once price history
+				// exists, instead of being silently ignored.
+				surcharge, _ := maxDownloadPriceUSD.Div(cfg.MaxDownloadPrice).Float64()
+				maxDownPriceSC = maxDownPriceSC.MulFloat(surcharge)
+			}
+			return pct.StoragePrice, pct.UploadPrice, maxDownPriceSC, nil
+		}
+	}
+
+	rstore, _ := cfg.MaxStorePrice.Div(decimal.NewFromFloat(scUsdRate)).Float64()
+	rdown, _ := maxDownloadPriceUSD.Div(decimal.NewFromFloat(scUsdRate)).Float64()
+	rup, _ := cfg.MaxUploadPrice.Div(decimal.NewFromFloat(scUsdRate)).Float64()
+	maxUpPriceSC = types.SiacoinPrecision.MulFloat(rup).Div64(1e12)
+	maxDownPriceSC = types.SiacoinPrecision.MulFloat(rdown).Div64(1e12)
+	maxStorePriceSC = types.SiacoinPrecision.MulFloat(rstore).Div64(1e12).Div64(4320)
+	return maxStorePriceSC, maxUpPriceSC, maxDownPriceSC, nil
+}
+
+// filterHosts is the hard-filter phase: it queries Sia Central for hosts
+// accepting contracts, old enough, and within the gouging caps. The
+// scoring phase ranks and trims this candidate set afterwards.
+func filterHosts(cfg config.Config, maxStorePriceSC, maxUpPriceSC, maxDownPriceSC types.Currency) ([]sia.HostDetails, error) {
+	filter := make(sia.HostFilter)
+	filter.WithAcceptingContracts(true)
+	filter.WithBenchmarked(true)
+	filter.WithMinAge(cfg.MinAge)
+	filter.WithMaxContractPrice(types.SiacoinPrecision.Div64(2))
+	filter.WithMaxUploadPrice(maxUpPriceSC)
+	filter.WithMaxDownloadPrice(maxDownPriceSC)
+	filter.WithMaxStoragePrice(maxStorePriceSC)
+	filter.WithMinUptime(cfg.MinUptime)
+	filter.WithMinDownloadSpeed(cfg.MinDownloadSpeed)
+	filter.WithMinUploadSpeed(cfg.MinUploadSpeed)
+
+	hosts, err := siaCentralClient.GetActiveHosts(filter, 0, 500)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get filtered hosts: %w", err)
+	}
+	return hosts, nil
+}
+
 func updateHostWhitelist() error {
+	cfg := currentConfig()
+
 	sc, _, err := siaCentralClient.GetExchangeRate()
 	if err != nil {
 		return fmt.Errorf("unable to get exchange rate")
@@ -77,40 +225,162 @@ func updateHostWhitelist() error {
 		return fmt.Errorf("usd rate not found or 0")
 	}
 
-	rstore, _ := maxStorePrice.Div(decimal.NewFromFloat(rate)).Float64()
-	rdown, _ := maxDownloadPrice.Div(decimal.NewFromFloat(rate)).Float64()
-	rup, _ := maxUploadPrice.Div(decimal.NewFromFloat(rate)).Float64()
-	maxUpPriceSC := types.SiacoinPrecision.MulFloat(rup).Div64(1e12)
-	maxDownPriceSC := types.SiacoinPrecision.MulFloat(rdown).Div64(1e12)
-	maxStorePriceSC := types.SiacoinPrecision.MulFloat(rstore).Div64(1e12).Div64(4320)
+	maxStorePriceSC, maxUpPriceSC, maxDownPriceSC, err := priceThresholds(cfg, rate, cfg.MaxDownloadPrice)
+	if err != nil {
+		return err
+	}
 
-	filter := make(sia.HostFilter)
-	filter.WithAcceptingContracts(true)
-	filter.WithBenchmarked(true)
-	filter.WithMinAge(minAge)
-	filter.WithMaxContractPrice(types.SiacoinPrecision.Div64(2))
-	filter.WithMaxUploadPrice(maxUpPriceSC)
-	filter.WithMaxDownloadPrice(maxDownPriceSC)
-	filter.WithMaxStoragePrice(maxStorePriceSC)
-	filter.WithMinUptime(minUptime)
-	filter.WithMinDownloadSpeed(minDownloadSpeed)
-	filter.WithMinUploadSpeed(minUploadSpeed)
-	filter.WithSort(sia.HostSortDownloadSpeed, true)
+	candidates, err := filterHosts(cfg, maxStorePriceSC, maxUpPriceSC, maxDownPriceSC)
+	if err != nil {
+		return err
+	}
 
-	hosts, err := siaCentralClient.GetActiveHosts(filter, 0, 500)
+	// the whitelist is shrinking below MinHosts; relax the download
+	// price cap by MigrationSurchargeMultiplier and try again rather
+	// than erroring out immediately.
+	if len(candidates) < cfg.MinHosts && cfg.MigrationSurchargeMultiplier.GreaterThan(decimal.NewFromFloat(1)) {
+		relaxedDownloadPrice := cfg.MaxDownloadPrice.Mul(cfg.MigrationSurchargeMultiplier)
+		logger.Info("relaxing max download price, too few hosts matched",
+			"matched", len(candidates), "relaxedDownloadPriceUSD", relaxedDownloadPrice.StringFixed(2))
+
+		_, _, relaxedMaxDownPriceSC, err := priceThresholds(cfg, rate, relaxedDownloadPrice)
+		if err != nil {
+			return err
+		}
+
+		candidates, err = filterHosts(cfg, maxStorePriceSC, maxUpPriceSC, relaxedMaxDownPriceSC)
+		if err != nil {
+			return err
+		}
+	}
+
+	blocked, err := blocklist.Load(blocklistFile)
 	if err != nil {
-		return fmt.Errorf("unable to get filtered hosts: %w", err)
+		return fmt.Errorf("unable to load blocklist: %w", err)
 	}
 
-	var contractPrice, storagePrice, downloadPrice, uploadPrice struct{ min, max, avg types.Currency }
-	var uptime, downloadSpeed, uploadSpeed struct{ min, max, avg decimal.Decimal }
-	var ages struct{ min, max, avg time.Duration }
-	keys := []types.SiaPublicKey{}
+	// supplement Sia Central's benchmarks with a connect-latency
+	// measurement from this renter's own vantage point, and drop hosts
+	// that fail it locally even though Sia Central's benchmark passed
+	// them. Hosts on the blocklist are dropped outright, and a host's
+	// consecutive local benchmark failures are tracked so it can be
+	// blocklisted if it keeps failing.
+	benchResults := hostBenchmarker.Run(context.Background(), candidates)
+	localLatencyScore := make(map[string]float64, len(candidates))
+	benchmarked := candidates[:0:0]
+	for _, host := range candidates {
+		if blocklist.Matches(host.PublicKey, string(host.NetAddress), blocked) {
+			continue
+		}
+
+		result, ok := benchResults[host.PublicKey]
+		if !ok {
+			benchmarked = append(benchmarked, host)
+			continue
+		}
+
+		failed := !result.Passed()
+		benchmarkFailures.Record(host.PublicKey, failed)
+		if failed {
+			continue
+		}
+		localLatencyScore[host.PublicKey] = 1 / result.Latency.Seconds()
+		benchmarked = append(benchmarked, host)
+	}
+	candidates = benchmarked
+
+	// a host with no entry in benchResults (the ok == false case above)
+	// skips the failure check, so also exclude any remaining candidate
+	// whose consecutive failure streak has reached blocklistFailureCycles.
+	if chronic := benchmarkFailures.Failing(blocklistFailureCycles); len(chronic) > 0 {
+		chronicSet := make(map[string]struct{}, len(chronic))
+		for _, pk := range chronic {
+			chronicSet[pk] = struct{}{}
+		}
+		filtered := candidates[:0:0]
+		for _, host := range candidates {
+			if _, ok := chronicSet[host.PublicKey]; ok {
+				continue
+			}
+			filtered = append(filtered, host)
+		}
+		candidates = filtered
+	}
+
+	// rank the full candidate set (topK of 0 disables the trim inside
+	// Rank) so diversity selection below can walk deeper than topK to
+	// backfill around quota-driven drops, and sticky retention after it
+	// can still consider hosts that scored just outside the result.
+	ranked := scoring.Rank(candidates, cfg.Weights, 0, localLatencyScore)
+
+	// cap concentration in any single country, ASN, or /24 subnet
+	// rather than letting the top-K alone decide geography; walk the
+	// full ranked pool and backfill past any quota-driven drops until
+	// topK diverse hosts are found or the pool is exhausted.
+	diverse, diversitySummary := diversity.Select(ranked, geoResolver, cfg.Diversity, cfg.TopK)
+	if diversitySummary.Dropped > 0 {
+		logger.Info("dropped hosts to satisfy diversity quotas", "dropped", diversitySummary.Dropped)
+	}
+
+	previousWhitelist, err := retention.Load(whitelistStateFile)
+	if err != nil {
+		return fmt.Errorf("unable to load previous whitelist: %w", err)
+	}
+	ranked = retention.Select(diverse, ranked, previousWhitelist, stickyTolerance)
+
+	if cfg.MinHosts > len(ranked) {
+		return fmt.Errorf("not enough hosts need %d got %d", cfg.MinHosts, len(ranked))
+	}
+
+	if tty {
+		tblDiversity := table.New("Country", "Count")
+		for country, count := range diversitySummary.CountryCounts {
+			tblDiversity.AddRow(country, count)
+		}
+		tblDiversity.Print()
+
+		tblASN := table.New("ASN", "Count")
+		for asn, count := range diversitySummary.ASNCounts {
+			tblASN.AddRow(diversity.FormatASN(asn), count)
+		}
+		tblASN.Print()
+	} else {
+		logger.Info("diversity summary", "countryCounts", diversitySummary.CountryCounts, "asnCounts", diversitySummary.ASNCounts, "subnetCounts", diversitySummary.SubnetCounts)
+	}
+
+	hosts := make([]sia.HostDetails, len(ranked))
+	keys := make([]types.SiaPublicKey, len(ranked))
+	for i, r := range ranked {
+		hosts[i] = r.Host
+		if err := keys[i].LoadString(r.Host.PublicKey); err != nil {
+			return fmt.Errorf("unable to load public key: %w", err)
+		}
+	}
 
-	if minHosts > len(hosts) {
-		return fmt.Errorf("not enough hosts need %d got %d", minHosts, len(hosts))
+	if dryRun {
+		if tty {
+			tbl := table.New("Public Key", "Score", "Age", "Uptime", "Collateral", "Storage", "Download", "Upload", "Price")
+			for _, r := range ranked {
+				b := r.Breakdown
+				tbl.AddRow(b.PublicKey, fmt.Sprintf("%.4f", r.Composite),
+					fmt.Sprintf("%.2f", b.Age), fmt.Sprintf("%.2f", b.Uptime), fmt.Sprintf("%.2f", b.Collateral),
+					fmt.Sprintf("%.2f", b.StorageRemaining), fmt.Sprintf("%.2f", b.DownloadSpeed),
+					fmt.Sprintf("%.2f", b.UploadSpeed), fmt.Sprintf("%.2f", b.Price))
+			}
+			tbl.Print()
+		} else {
+			for _, r := range ranked {
+				logger.Info("host", "publicKey", r.Breakdown.PublicKey, "netAddress", string(r.Host.NetAddress), "score", r.Composite)
+			}
+		}
+		logger.Info("dry-run complete, whitelist not updated", "hostsRanked", len(ranked))
+		return nil
 	}
 
+	var contractPrice, storagePrice, downloadPrice, uploadPrice, combinedPrice struct{ min, max, avg types.Currency }
+	var uptime, downloadSpeed, uploadSpeed struct{ min, max, avg decimal.Decimal }
+	var ages struct{ min, max, avg time.Duration }
+
 	for i, host := range hosts {
 		contractPrice.avg = contractPrice.avg.Add(host.Settings.ContractPrice)
 		storagePrice.avg = storagePrice.avg.Add(host.Settings.StoragePrice)
@@ -118,6 +388,21 @@ func updateHostWhitelist() error {
 		uploadPrice.avg = uploadPrice.avg.Add(host.Settings.UploadBandwidthPrice)
 		uptime.avg = uptime.avg.Add(decimal.NewFromFloat32(host.EstimatedUptime))
 
+		// combinedPrice feeds the selector_*_price_sc metrics; it's the
+		// same storage+upload+download sum the scoring package uses.
+		combined := host.Settings.StoragePrice.Add(host.Settings.UploadBandwidthPrice).Add(host.Settings.DownloadBandwidthPrice)
+		combinedPrice.avg = combinedPrice.avg.Add(combined)
+		if i == 0 {
+			combinedPrice.min = combined
+			combinedPrice.max = combined
+		}
+		if combined.Cmp(combinedPrice.min) < 0 {
+			combinedPrice.min = combined
+		}
+		if combined.Cmp(combinedPrice.max) > 0 {
+			combinedPrice.max = combined
+		}
+
 		upSeconds := decimal.New(int64(host.Benchmark.UploadTime), 0).Div(decimal.New(1000, 0))
 		downSeconds := decimal.New(int64(host.Benchmark.DownloadTime), 0).Div(decimal.New(1000, 0))
 		upBps := decimal.New(int64(host.Benchmark.DataSize)*8, 0).Div(upSeconds)
@@ -194,34 +479,43 @@ func updateHostWhitelist() error {
 		if ages.max < age {
 			ages.max = age
 		}
-
-		var spk types.SiaPublicKey
-		if err := spk.LoadString(host.PublicKey); err != nil {
-			return fmt.Errorf("unable to load public key: %w", err)
-		}
-		keys = append(keys, spk)
 	}
 
 	contractPrice.avg = contractPrice.avg.Div64(uint64(len(hosts)))
 	storagePrice.avg = storagePrice.avg.Div64(uint64(len(hosts)))
 	downloadPrice.avg = downloadPrice.avg.Div64(uint64(len(hosts)))
 	uploadPrice.avg = uploadPrice.avg.Div64(uint64(len(hosts)))
+	combinedPrice.avg = combinedPrice.avg.Div64(uint64(len(hosts)))
 	uptime.avg = uptime.avg.Div(decimal.New(int64(len(hosts)), 0))
 	downloadSpeed.avg = downloadSpeed.avg.Div(decimal.New(int64(len(hosts)), 0))
 	uploadSpeed.avg = uploadSpeed.avg.Div(decimal.New(int64(len(hosts)), 0))
 	ages.avg = ages.avg / time.Duration(len(hosts))
 
-	log.Printf("Matching %d hosts", len(hosts))
-	tbl := table.New("", "Min", "Avg", "Max")
-	tbl.AddRow("Contract Price", contractPrice.min.HumanString(), contractPrice.avg.HumanString(), contractPrice.max.HumanString())
-	tbl.AddRow("Storage", storagePrice.min.Mul64(1e12).Mul64(4320).HumanString(), storagePrice.avg.Mul64(1e12).Mul64(4320).HumanString(), storagePrice.max.Mul64(1e12).Mul64(4320).HumanString())
-	tbl.AddRow("Download", downloadPrice.min.Mul64(1e12).HumanString(), downloadPrice.avg.Mul64(1e12).HumanString(), downloadPrice.max.Mul64(1e12).HumanString())
-	tbl.AddRow("Upload", uploadPrice.min.Mul64(1e12).HumanString(), uploadPrice.avg.Mul64(1e12).HumanString(), uploadPrice.max.Mul64(1e12).HumanString())
-	tbl.AddRow("Uptime", uptime.min.StringFixed(2)+"%", uptime.avg.StringFixed(2)+"%", uptime.max.StringFixed(2)+"%")
-	tbl.AddRow("Age", formatAge(ages.min), formatAge(ages.avg), formatAge(ages.max))
-	tbl.AddRow("Download Speed", formatBpsString(downloadSpeed.min), formatBpsString(downloadSpeed.avg), formatBpsString(downloadSpeed.max))
-	tbl.AddRow("Upload Speed", formatBpsString(uploadSpeed.min), formatBpsString(uploadSpeed.avg), formatBpsString(uploadSpeed.max))
-	tbl.Print()
+	if tty {
+		tbl := table.New("", "Min", "Avg", "Max")
+		tbl.AddRow("Contract Price", contractPrice.min.HumanString(), contractPrice.avg.HumanString(), contractPrice.max.HumanString())
+		tbl.AddRow("Storage", storagePrice.min.Mul64(1e12).Mul64(4320).HumanString(), storagePrice.avg.Mul64(1e12).Mul64(4320).HumanString(), storagePrice.max.Mul64(1e12).Mul64(4320).HumanString())
+		tbl.AddRow("Download", downloadPrice.min.Mul64(1e12).HumanString(), downloadPrice.avg.Mul64(1e12).HumanString(), downloadPrice.max.Mul64(1e12).HumanString())
+		tbl.AddRow("Upload", uploadPrice.min.Mul64(1e12).HumanString(), uploadPrice.avg.Mul64(1e12).HumanString(), uploadPrice.max.Mul64(1e12).HumanString())
+		tbl.AddRow("Uptime", uptime.min.StringFixed(2)+"%", uptime.avg.StringFixed(2)+"%", uptime.max.StringFixed(2)+"%")
+		tbl.AddRow("Age", formatAge(ages.min), formatAge(ages.avg), formatAge(ages.max))
+		tbl.AddRow("Download Speed", formatBpsString(downloadSpeed.min), formatBpsString(downloadSpeed.avg), formatBpsString(downloadSpeed.max))
+		tbl.AddRow("Upload Speed", formatBpsString(uploadSpeed.min), formatBpsString(uploadSpeed.avg), formatBpsString(uploadSpeed.max))
+		tbl.Print()
+	} else {
+		for _, host := range hosts {
+			logger.Info("host", "publicKey", host.PublicKey, "netAddress", string(host.NetAddress))
+		}
+	}
+
+	minPriceSC, _ := new(big.Rat).SetFrac(combinedPrice.min.Big(), types.SiacoinPrecision.Big()).Float64()
+	avgPriceSC, _ := new(big.Rat).SetFrac(combinedPrice.avg.Big(), types.SiacoinPrecision.Big()).Float64()
+	avgUptime, _ := uptime.avg.Float64()
+	avgDownloadBps, _ := downloadSpeed.avg.Float64()
+	if selectorMetrics != nil {
+		selectorMetrics.Observe(len(hosts), minPriceSC, avgPriceSC, avgUptime, avgDownloadBps, rate)
+	}
+	logger.Info("whitelist updated", "hostsMatched", len(hosts), "minPriceSC", minPriceSC, "avgPriceSC", avgPriceSC, "avgUptime", avgUptime, "avgDownloadBps", avgDownloadBps, "scUsdRate", rate)
 
 	siaPass, err := build.APIPassword()
 	if err != nil {
@@ -238,21 +532,142 @@ func updateHostWhitelist() error {
 		Password: siaPass,
 	})
 
+	// netAddresses is matched in both whitelist and blacklist mode, but
+	// in whitelist mode a match is *added* to the filtered set rather
+	// than excluded from it - the opposite of what a blacklist overlay
+	// would need, so it can't be reused here to merge in the blocklist.
+	// The user blocklist and chronic local benchmark failures are
+	// instead enforced earlier, by excluding those hosts from candidates
+	// before ranking, so they never make it into keys here.
 	err = siaClient.HostDbFilterModePost(modules.HostDBActiveWhitelist, keys, nil)
 	if err != nil {
 		return fmt.Errorf("unable to update hostdb filter: %w", err)
 	}
 
+	// persist this cycle's selection so the next cycle's sticky
+	// retention can recognize hosts that were already whitelisted.
+	if err := retention.Save(whitelistStateFile, retention.PublicKeys(ranked)); err != nil {
+		logger.Warn("unable to persist whitelist state", "error", err)
+	}
+
 	return nil
 }
 
+// pollPriceHistory records a price sample immediately, then once every
+// 24 hours for as long as the process runs.
+func pollPriceHistory() {
+	record := func() {
+		s, err := pricetracker.Poll(siaCentralClient)
+		if err != nil {
+			logger.Warn("unable to poll price history", "error", err)
+			return
+		}
+		if err := priceTracker.Record(s); err != nil {
+			logger.Warn("unable to record price history", "error", err)
+		}
+	}
+
+	record()
+	for range time.Tick(24 * time.Hour) {
+		record()
+	}
+}
+
+// runHistoryDump implements the `history dump` subcommand, printing the
+// stored price history series to stdout.
+func runHistoryDump(args []string) {
+	fs := flag.NewFlagSet("history dump", flag.ExitOnError)
+	fs.StringVar(&priceHistoryDB, "price-history-db", "price-history.db", "path to the price history database")
+	fs.Parse(args)
+
+	t, err := pricetracker.Open(priceHistoryDB)
+	if err != nil {
+		log.Fatalln("[FATAL]", err)
+	}
+	defer t.Close()
+
+	samples, err := t.All()
+	if err != nil {
+		log.Fatalln("[FATAL] unable to read price history:", err)
+	}
+
+	tbl := table.New("Timestamp", "SC/USD", "Storage", "Upload", "Download")
+	for _, s := range samples {
+		tbl.AddRow(
+			s.Timestamp.Format(time.RFC3339),
+			s.SCUSDRate.StringFixed(4),
+			s.MedianStoragePrice.Mul64(1e12).Mul64(4320).HumanString(),
+			s.MedianUploadPrice.Mul64(1e12).HumanString(),
+			s.MedianDownloadPrice.Mul64(1e12).HumanString(),
+		)
+	}
+	tbl.Print()
+}
+
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "history" && os.Args[2] == "dump" {
+		runHistoryDump(os.Args[3:])
+		return
+	}
+
+	flag.StringVar(&configPath, "config", "", "path to the selector config file, overrides "+config.EnvVar)
+	flag.StringVar(&priceHistoryDB, "price-history-db", "price-history.db", "path to the price history database")
+	flag.IntVar(&priceHistoryDays, "price-history-days", 90, "trailing window, in days, used to compute price percentiles")
+	flag.Float64Var(&pricePercentile, "price-percentile", 60, "percentile of trailing market prices used as the gouging threshold")
+	flag.BoolVar(&dryRun, "dry-run", false, "print the ranked host table without updating the hostdb whitelist")
+	flag.IntVar(&benchmarkConcurrency, "benchmark-concurrency", 4, "number of hosts to benchmark at once")
+	flag.DurationVar(&benchmarkTimeout, "benchmark-timeout", 30*time.Second, "per-host timeout for the local benchmark")
+	flag.StringVar(&geoipDB, "geoip-db", "", "path to a MaxMind GeoLite2 ASN or Country database used for host diversification")
+	flag.BoolVar(&tty, "tty", false, "print human-readable tables instead of structured JSON logs, for interactive runs")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "address to serve Prometheus metrics on, disabled if empty")
+	flag.StringVar(&blocklistFile, "blocklist-file", "", "path to a file of blocklisted public keys/net addresses, one per line")
+	flag.Float64Var(&stickyTolerance, "sticky-tolerance", 0.05, "score delta, as a fraction of the top-K cutoff, within which a previously-whitelisted host is retained")
+	flag.StringVar(&whitelistStateFile, "whitelist-state-file", "whitelist-state.txt", "path used to persist the previous cycle's whitelist for sticky retention")
+	flag.Parse()
+
+	configPath = config.Path(configPath)
+	if err := loadConfig(); err != nil {
+		logger.Error("unable to load config", "error", err)
+		os.Exit(1)
+	}
+
+	var err error
+	priceTracker, err = pricetracker.Open(priceHistoryDB)
+	if err != nil {
+		logger.Error("unable to open price history database", "error", err)
+		os.Exit(1)
+	}
+	defer priceTracker.Close()
+
+	hostBenchmarker = benchmark.New(benchmarkConcurrency, benchmarkTimeout, benchmarkCacheTTL)
+
+	geoResolver, err = diversity.OpenGeoLite2(geoipDB)
+	if err != nil {
+		logger.Error("unable to open geoip database", "error", err)
+		os.Exit(1)
+	}
+	if closer, ok := geoResolver.(interface{ Close() error }); ok {
+		defer closer.Close()
+	}
+
+	selectorMetrics = metrics.New()
+	if len(metricsAddr) > 0 {
+		go func() {
+			if err := selectorMetrics.Serve(metricsAddr); err != nil {
+				logger.Error("metrics server exited", "error", err)
+			}
+		}()
+	}
+
+	go watchReloadSignal()
+	go pollPriceHistory()
 
 	for {
-		log.Println("Updating Whitelist")
+		logger.Info("updating whitelist")
 		if err := updateHostWhitelist(); err != nil {
-			log.Println("[WARN]", err)
+			logger.Warn("unable to update whitelist", "error", err)
+			selectorMetrics.IncUpdateErrors()
 		}
-		time.Sleep(time.Hour * 8)
+		time.Sleep(currentConfig().UpdateInterval)
 	}
 }
@@ -0,0 +1,161 @@
+// Package benchmark measures host connect latency directly from the
+// machine running the selector, to supplement Sia Central's benchmarks.
+// Sia Central measures every host from one server, which may not reflect
+// this renter's local peering; dialing each candidate ourselves gives a
+// vantage-point-accurate latency number to blend into scoring.
+//
+// This only measures TCP connect latency, not RHP throughput: sampling
+// real upload/download speed means exercising the RHP2/RHP3 sector
+// RPCs, which need a funded renter contract per host. A selection tool
+// that doesn't hold a renter allowance can't do that, and a raw socket
+// push/pull of random bytes without the RHP handshake measures nothing
+// meaningful about the host, so it isn't attempted here.
+//
+// No data is transferred, so there is no size setting for the benchmark,
+// and hosts are not skipped for falling below a local minimum download
+// or upload speed, since neither figure is measured.
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/siacentral/apisdkgo/sia"
+)
+
+// Result is a single host's local measurement.
+type Result struct {
+	PublicKey  string
+	NetAddress string
+	Latency    time.Duration
+	Timestamp  time.Time
+	Err        error
+}
+
+// Passed reports whether the host was reachable and connected within the
+// benchmark's timeout.
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+type cacheEntry struct {
+	result  Result
+	expires time.Time
+}
+
+// cache is a TTL cache of benchmark results, keyed by host public key, so
+// repeat cycles don't re-dial a host more often than necessary.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (c *cache) get(pubkey string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[pubkey]
+	if !ok || time.Now().After(e.expires) {
+		return Result{}, false
+	}
+	return e.result, true
+}
+
+func (c *cache) set(pubkey string, r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[pubkey] = cacheEntry{result: r, expires: time.Now().Add(c.ttl)}
+}
+
+// Benchmarker runs bounded, per-host-timeout network benchmarks against
+// candidate hosts.
+type Benchmarker struct {
+	concurrency int
+	timeout     time.Duration
+	cache       *cache
+}
+
+// New creates a Benchmarker that runs at most concurrency benchmarks at
+// once, aborts a single host's benchmark after timeout, and caches
+// results for cacheTTL.
+func New(concurrency int, timeout, cacheTTL time.Duration) *Benchmarker {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Benchmarker{
+		concurrency: concurrency,
+		timeout:     timeout,
+		cache:       newCache(cacheTTL),
+	}
+}
+
+// Run benchmarks every host in hosts, using up to b.concurrency workers,
+// and returns a result per host keyed by public key. Cached results
+// within TTL are returned without re-dialing the host.
+func (b *Benchmarker) Run(ctx context.Context, hosts []sia.HostDetails) map[string]Result {
+	results := make(map[string]Result, len(hosts))
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, b.concurrency)
+	var wg sync.WaitGroup
+
+	for _, host := range hosts {
+		if cached, ok := b.cache.get(host.PublicKey); ok {
+			mu.Lock()
+			results[host.PublicKey] = cached
+			mu.Unlock()
+			continue
+		}
+
+		host := host
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			hostCtx, cancel := context.WithTimeout(ctx, b.timeout)
+			defer cancel()
+
+			r := b.benchmarkHost(hostCtx, host)
+			b.cache.set(host.PublicKey, r)
+
+			mu.Lock()
+			results[host.PublicKey] = r
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// benchmarkHost dials host directly and measures connect latency. It
+// does not attempt to sample throughput; see the package doc comment.
+func (b *Benchmarker) benchmarkHost(ctx context.Context, host sia.HostDetails) Result {
+	result := Result{
+		PublicKey:  host.PublicKey,
+		NetAddress: string(host.NetAddress),
+		Timestamp:  time.Now(),
+	}
+
+	dialer := net.Dialer{}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", string(host.NetAddress))
+	if err != nil {
+		result.Err = fmt.Errorf("unable to dial host: %w", err)
+		return result
+	}
+	defer conn.Close()
+	result.Latency = time.Since(start)
+
+	return result
+}
@@ -0,0 +1,83 @@
+// Package blocklist maintains the hosts that must never be whitelisted:
+// entries from an operator-maintained file, plus hosts the local
+// benchmark keeps failing cycle after cycle.
+package blocklist
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Load reads a blocklist file containing one public key or net address
+// per line. Blank lines and lines starting with "#" are ignored. A
+// missing file is treated as an empty blocklist, since operators don't
+// always maintain one.
+func Load(path string) (map[string]struct{}, error) {
+	entries := make(map[string]struct{})
+	if len(path) == 0 {
+		return entries, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries[line] = struct{}{}
+	}
+	return entries, scanner.Err()
+}
+
+// Matches reports whether a host's public key or net address appears in
+// blocked.
+func Matches(publicKey, netAddress string, blocked map[string]struct{}) bool {
+	if _, ok := blocked[publicKey]; ok {
+		return true
+	}
+	_, ok := blocked[netAddress]
+	return ok
+}
+
+// FailureTracker counts consecutive local-benchmark failures per host,
+// so a host that keeps failing can be blocklisted outright rather than
+// just excluded from the one cycle that measured it.
+type FailureTracker struct {
+	streaks map[string]int
+}
+
+// NewFailureTracker returns an empty tracker.
+func NewFailureTracker() *FailureTracker {
+	return &FailureTracker{streaks: make(map[string]int)}
+}
+
+// Record updates publicKey's consecutive-failure streak: a pass resets
+// it to zero, a failure increments it.
+func (t *FailureTracker) Record(publicKey string, failed bool) {
+	if failed {
+		t.streaks[publicKey]++
+		return
+	}
+	delete(t.streaks, publicKey)
+}
+
+// Failing returns the public keys that have failed at least threshold
+// consecutive cycles.
+func (t *FailureTracker) Failing(threshold int) []string {
+	var keys []string
+	for k, n := range t.streaks {
+		if n >= threshold {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
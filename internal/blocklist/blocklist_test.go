@@ -0,0 +1,103 @@
+package blocklist
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blocklist.txt")
+	contents := "pubkey1\n# a comment\n\n  pubkey2  \nhost.example.com:9982\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct{}{
+		"pubkey1":                {},
+		"pubkey2":                {},
+		"host.example.com:9982": {},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("got %v, want %v", entries, want)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	entries, err := Load(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected an empty blocklist, got %v", entries)
+	}
+}
+
+func TestLoadEmptyPath(t *testing.T) {
+	entries, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected an empty blocklist, got %v", entries)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	blocked := map[string]struct{}{
+		"pubkey1":         {},
+		"1.2.3.4:9982": {},
+	}
+
+	tests := []struct {
+		publicKey, netAddress string
+		want                  bool
+	}{
+		{"pubkey1", "5.6.7.8:9982", true},
+		{"pubkey2", "1.2.3.4:9982", true},
+		{"pubkey2", "5.6.7.8:9982", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.publicKey, tt.netAddress, blocked); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.publicKey, tt.netAddress, got, tt.want)
+		}
+	}
+}
+
+func TestFailureTracker(t *testing.T) {
+	tracker := NewFailureTracker()
+
+	tracker.Record("pubkey1", true)
+	tracker.Record("pubkey1", true)
+	tracker.Record("pubkey2", true)
+	tracker.Record("pubkey3", false)
+
+	if got := tracker.Failing(2); !sameKeys(got, []string{"pubkey1"}) {
+		t.Fatalf("Failing(2) = %v, want [pubkey1]", got)
+	}
+
+	tracker.Record("pubkey2", true)
+	if got := tracker.Failing(2); !sameKeys(got, []string{"pubkey1", "pubkey2"}) {
+		t.Fatalf("Failing(2) = %v, want [pubkey1 pubkey2]", got)
+	}
+
+	// a pass resets the streak.
+	tracker.Record("pubkey1", false)
+	if got := tracker.Failing(2); !sameKeys(got, []string{"pubkey2"}) {
+		t.Fatalf("Failing(2) after reset = %v, want [pubkey2]", got)
+	}
+}
+
+func sameKeys(got, want []string) bool {
+	sort.Strings(got)
+	sort.Strings(want)
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,215 @@
+// Package config loads and validates the selection policy used by
+// selectord. Previously these knobs were hardcoded package-level vars in
+// cmd/selectord; they now live in a YAML or JSON file so operators can
+// retune the selector without a rebuild.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVar is the environment variable checked for a config path when the
+// --config flag is not set.
+const EnvVar = "SIA_SELECTOR_CONFIG"
+
+// Config controls the selection policy applied to the Sia Central host
+// list each cycle.
+type Config struct {
+	// MinHosts is the minimum number of hosts that must pass the filter.
+	// An error is returned if fewer hosts remain after the gouging caps
+	// are applied, unless MigrationSurchargeMultiplier allows the caps
+	// to relax first.
+	MinHosts int `yaml:"min_hosts" json:"minHosts"`
+
+	// MaxDownloadPrice, MaxUploadPrice, and MaxStorePrice are USD gouging
+	// ceilings for download, upload, and storage respectively.
+	MaxDownloadPrice decimal.Decimal `yaml:"max_download_price" json:"maxDownloadPrice"`
+	MaxUploadPrice   decimal.Decimal `yaml:"max_upload_price" json:"maxUploadPrice"`
+	MaxStorePrice    decimal.Decimal `yaml:"max_store_price" json:"maxStorePrice"`
+
+	// MinAge is the minimum host age, in blocks.
+	MinAge uint64 `yaml:"min_age" json:"minAge"`
+	// MinUptime is the minimum estimated uptime percentage.
+	MinUptime float64 `yaml:"min_uptime" json:"minUptime"`
+	// MinDownloadSpeed and MinUploadSpeed are minimum benchmarked
+	// throughputs, in bits per second.
+	MinDownloadSpeed uint64 `yaml:"min_download_speed" json:"minDownloadSpeed"`
+	MinUploadSpeed   uint64 `yaml:"min_upload_speed" json:"minUploadSpeed"`
+
+	// UpdateInterval is how often the whitelist is recomputed. Replaces
+	// the hardcoded 8-hour sleep in the selection loop.
+	UpdateInterval time.Duration `yaml:"update_interval" json:"updateInterval"`
+
+	// MigrationSurchargeMultiplier relaxes MaxDownloadPrice when the
+	// whitelist is shrinking below MinHosts, borrowed from renterd's
+	// gouging model: rather than erroring out immediately, the selector
+	// retries with MaxDownloadPrice multiplied by this value so it can
+	// accept slightly more expensive hosts to keep the whitelist full.
+	// A value of 1 (or 0) disables the surcharge.
+	MigrationSurchargeMultiplier decimal.Decimal `yaml:"migration_surcharge_multiplier" json:"migrationSurchargeMultiplier"`
+
+	// TopK is the number of hosts kept after scoring. Replaces the
+	// previous behavior of taking up to 500 hosts sorted by download
+	// speed alone.
+	TopK int `yaml:"top_k" json:"topK"`
+
+	// Weights controls how much each sub-score contributes to a host's
+	// composite score during the scoring phase.
+	Weights Weights `yaml:"weights" json:"weights"`
+
+	// Diversity caps how concentrated the selected set can be in a
+	// single country, ASN, or /24 subnet.
+	Diversity Diversity `yaml:"diversity" json:"diversity"`
+}
+
+// Diversity limits how many selected hosts may share a country, ASN, or
+// /24 subnet, so the whitelist isn't concentrated in one datacenter. A
+// value of 0 disables that particular quota.
+type Diversity struct {
+	MaxPerCountry int `yaml:"max_per_country" json:"maxPerCountry"`
+	MaxPerASN     int `yaml:"max_per_asn" json:"maxPerAsn"`
+	MaxPerSubnet  int `yaml:"max_per_subnet" json:"maxPerSubnet"`
+}
+
+// Weights holds the per-criterion weights used by the scoring package to
+// combine a host's normalized sub-scores into a single composite score.
+// A weight of 0 excludes that criterion entirely.
+type Weights struct {
+	Age              float64 `yaml:"age" json:"age"`
+	Uptime           float64 `yaml:"uptime" json:"uptime"`
+	Collateral       float64 `yaml:"collateral" json:"collateral"`
+	StorageRemaining float64 `yaml:"storage_remaining" json:"storageRemaining"`
+	DownloadSpeed    float64 `yaml:"download_speed" json:"downloadSpeed"`
+	UploadSpeed      float64 `yaml:"upload_speed" json:"uploadSpeed"`
+	Price            float64 `yaml:"price" json:"price"`
+
+	// LocalBenchmark weights the locally-measured connect latency from
+	// the benchmark package, as opposed to DownloadSpeed/UploadSpeed which
+	// are Sia Central's throughput measurements from its own vantage point.
+	LocalBenchmark float64 `yaml:"local_benchmark" json:"localBenchmark"`
+}
+
+// Defaults returns the selection policy that was previously hardcoded in
+// cmd/selectord.
+func Defaults() Config {
+	return Config{
+		// minimum of 100 hosts, including a few extra for churn, will throw an
+		// error if not enough hosts are available
+		MinHosts: 100,
+		// $10 USD/TB
+		MaxDownloadPrice: decimal.NewFromFloat(10),
+		// $1.00 USD/TB
+		MaxUploadPrice: decimal.NewFromFloat(1),
+		// $2.00 USD/TB/mo
+		MaxStorePrice: decimal.NewFromFloat(2),
+		// at least a month old (30 days * 144 blocks)
+		MinAge: 30 * 144,
+		// 80% as measured by Sia Central
+		MinUptime: 80,
+		// 5Mbps as measured by Sia Central
+		MinDownloadSpeed: 5e6,
+		// 1Mbps as measured by Sia Central
+		MinUploadSpeed: 1e6,
+
+		UpdateInterval: time.Hour * 8,
+
+		MigrationSurchargeMultiplier: decimal.NewFromFloat(1),
+
+		TopK: 500,
+
+		Weights: Weights{
+			Age:              1,
+			Uptime:           1,
+			Collateral:       1,
+			StorageRemaining: 1,
+			DownloadSpeed:    1,
+			UploadSpeed:      1,
+			Price:            1,
+			LocalBenchmark:   1,
+		},
+
+		Diversity: Diversity{
+			MaxPerCountry: 15,
+			MaxPerASN:     5,
+			MaxPerSubnet:  2,
+		},
+	}
+}
+
+// Validate returns an error if the config contains values the selector
+// cannot operate with.
+func (c Config) Validate() error {
+	if c.MinHosts <= 0 {
+		return fmt.Errorf("min_hosts must be positive")
+	}
+	if c.MaxDownloadPrice.IsNegative() || c.MaxUploadPrice.IsNegative() || c.MaxStorePrice.IsNegative() {
+		return fmt.Errorf("max prices must not be negative")
+	}
+	if c.MinUptime < 0 || c.MinUptime > 100 {
+		return fmt.Errorf("min_uptime must be between 0 and 100")
+	}
+	if c.UpdateInterval <= 0 {
+		return fmt.Errorf("update_interval must be positive")
+	}
+	if c.MigrationSurchargeMultiplier.IsNegative() {
+		return fmt.Errorf("migration_surcharge_multiplier must not be negative")
+	}
+	if c.TopK <= 0 {
+		return fmt.Errorf("top_k must be positive")
+	}
+	if c.Weights.Age < 0 || c.Weights.Uptime < 0 || c.Weights.Collateral < 0 || c.Weights.StorageRemaining < 0 ||
+		c.Weights.DownloadSpeed < 0 || c.Weights.UploadSpeed < 0 || c.Weights.Price < 0 || c.Weights.LocalBenchmark < 0 {
+		return fmt.Errorf("weights must not be negative")
+	}
+	if c.Diversity.MaxPerCountry < 0 || c.Diversity.MaxPerASN < 0 || c.Diversity.MaxPerSubnet < 0 {
+		return fmt.Errorf("diversity quotas must not be negative")
+	}
+	return nil
+}
+
+// Load reads and validates a Config from path. The file format is chosen
+// by its extension: .yml and .yaml are parsed as YAML, everything else
+// as JSON. Fields absent from the file fall back to Defaults().
+func Load(path string) (Config, error) {
+	cfg := Defaults()
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to read config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		if err := yaml.Unmarshal(buf, &cfg); err != nil {
+			return Config{}, fmt.Errorf("unable to parse config: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(buf, &cfg); err != nil {
+			return Config{}, fmt.Errorf("unable to parse config: %w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Path resolves the config path from the --config flag value, falling
+// back to the SIA_SELECTOR_CONFIG environment variable. An empty string
+// is returned if neither is set, in which case callers should use
+// Defaults().
+func Path(flagValue string) string {
+	if len(flagValue) > 0 {
+		return flagValue
+	}
+	return os.Getenv(EnvVar)
+}
@@ -0,0 +1,132 @@
+// Package diversity caps how concentrated the selected host set can be
+// in a single country, ASN, or /24 subnet. Taking the highest-scoring
+// hosts alone risks piling storage into one datacenter; this package
+// walks the ranked list and greedily accepts hosts only while they stay
+// under the configured per-region quotas.
+package diversity
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/n8maninger/sia-host-selector/internal/config"
+	"github.com/n8maninger/sia-host-selector/internal/scoring"
+)
+
+// Resolver maps a host IP to the geographic and network metadata used
+// for diversification. The GeoLite2 resolver is the normal
+// implementation; NoopResolver is used when no database is configured.
+type Resolver interface {
+	// Lookup returns the ISO country code and ASN for ip. An empty
+	// country or a zero ASN means "unknown", and hosts with an unknown
+	// value for a criterion are exempt from that criterion's quota
+	// rather than all being bucketed together.
+	Lookup(ip net.IP) (country string, asn uint32)
+}
+
+// noopResolver is used when no GeoLite2 database is configured; every
+// host is "unknown" for country and ASN, so only the /24 subnet quota
+// (derived from the host's advertised address, not the resolver) has any
+// effect.
+type noopResolver struct{}
+
+func (noopResolver) Lookup(net.IP) (string, uint32) { return "", 0 }
+
+// NoopResolver is the zero-value Resolver: it resolves nothing.
+var NoopResolver Resolver = noopResolver{}
+
+// Subnet returns the /24 CIDR containing netAddress's IPv4 host, or the
+// /64 containing an IPv6 host. netAddress may be "host:port" or a bare
+// IP; "" is returned if the host isn't an IP literal (e.g. a hostname).
+func Subnet(netAddress string) string {
+	host, _, err := net.SplitHostPort(netAddress)
+	if err != nil {
+		host = netAddress
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	ip4 := ip.To4()
+	if ip4 == nil {
+		// IPv6: fall back to the /64 to get an analogous "same
+		// operator" grouping.
+		mask := net.CIDRMask(64, 128)
+		return ip.Mask(mask).String() + "/64"
+	}
+	mask := net.CIDRMask(24, 32)
+	return ip4.Mask(mask).String() + "/24"
+}
+
+// Summary reports how the selected set is distributed, for the
+// diversity table printed alongside the existing price/speed table.
+type Summary struct {
+	CountryCounts map[string]int
+	ASNCounts     map[uint32]int
+	SubnetCounts  map[string]int
+	Dropped       int
+}
+
+// Select walks ranked (already sorted best-first) and greedily keeps a
+// host only if accepting it would not exceed q's per-country, per-ASN,
+// or per-subnet quotas. A quota of 0 disables that check. If topK > 0,
+// Select stops as soon as topK hosts have been accepted, backfilling
+// from deeper in ranked past whatever quota-driven gaps it hit along the
+// way instead of hard-cutting to topK before quotas are even applied;
+// topK <= 0 walks the entire ranked list.
+func Select(ranked []scoring.RankedHost, resolver Resolver, q config.Diversity, topK int) ([]scoring.RankedHost, Summary) {
+	if resolver == nil {
+		resolver = NoopResolver
+	}
+
+	summary := Summary{
+		CountryCounts: make(map[string]int),
+		ASNCounts:     make(map[uint32]int),
+		SubnetCounts:  make(map[string]int),
+	}
+
+	selected := make([]scoring.RankedHost, 0, len(ranked))
+	for _, r := range ranked {
+		if topK > 0 && len(selected) >= topK {
+			break
+		}
+
+		netAddress := string(r.Host.NetAddress)
+		host, _, _ := net.SplitHostPort(netAddress)
+		country, asn := resolver.Lookup(net.ParseIP(host))
+		subnet := Subnet(netAddress)
+
+		if q.MaxPerCountry > 0 && country != "" && summary.CountryCounts[country] >= q.MaxPerCountry {
+			summary.Dropped++
+			continue
+		}
+		if q.MaxPerASN > 0 && asn != 0 && summary.ASNCounts[asn] >= q.MaxPerASN {
+			summary.Dropped++
+			continue
+		}
+		if q.MaxPerSubnet > 0 && subnet != "" && summary.SubnetCounts[subnet] >= q.MaxPerSubnet {
+			summary.Dropped++
+			continue
+		}
+
+		if country != "" {
+			summary.CountryCounts[country]++
+		}
+		if asn != 0 {
+			summary.ASNCounts[asn]++
+		}
+		if subnet != "" {
+			summary.SubnetCounts[subnet]++
+		}
+		selected = append(selected, r)
+	}
+	return selected, summary
+}
+
+// FormatASN renders an ASN for display, e.g. "AS14061".
+func FormatASN(asn uint32) string {
+	if asn == 0 {
+		return "unknown"
+	}
+	return "AS" + strconv.FormatUint(uint64(asn), 10)
+}
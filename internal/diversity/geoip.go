@@ -0,0 +1,49 @@
+package diversity
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// geoLite2Resolver resolves country and ASN from a bundled MaxMind
+// GeoLite2 database; it is the only source of country and ASN used for
+// diversification.
+type geoLite2Resolver struct {
+	db *geoip2.Reader
+}
+
+// OpenGeoLite2 opens a GeoLite2-ASN or GeoLite2-Country mmdb file at
+// path. The returned Resolver answers whichever of country/ASN the
+// database supports and reports the other as unknown.
+func OpenGeoLite2(path string) (Resolver, error) {
+	if len(path) == 0 {
+		return NoopResolver, nil
+	}
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open GeoLite2 database: %w", err)
+	}
+	return &geoLite2Resolver{db: db}, nil
+}
+
+// Close releases the underlying mmdb file.
+func (r *geoLite2Resolver) Close() error {
+	return r.db.Close()
+}
+
+func (r *geoLite2Resolver) Lookup(ip net.IP) (country string, asn uint32) {
+	if ip == nil {
+		return "", 0
+	}
+
+	if rec, err := r.db.ASN(ip); err == nil {
+		asn = uint32(rec.AutonomousSystemNumber)
+	}
+	if rec, err := r.db.Country(ip); err == nil {
+		country = rec.Country.IsoCode
+	}
+	return country, asn
+}
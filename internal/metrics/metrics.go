@@ -0,0 +1,88 @@
+// Package metrics exposes the selector's per-cycle results as Prometheus
+// gauges/counters, served over HTTP so operators can alert on drift
+// (e.g. matched host count dropping, average price spiking) without
+// scraping the structured logs.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the selector's exported Prometheus collectors.
+type Metrics struct {
+	hostsMatched        prometheus.Gauge
+	minPriceSC          prometheus.Gauge
+	avgPriceSC          prometheus.Gauge
+	avgUptime           prometheus.Gauge
+	avgDownloadBps      prometheus.Gauge
+	lastUpdateTimestamp prometheus.Gauge
+	scUsdRate           prometheus.Gauge
+	updateErrors        prometheus.Counter
+}
+
+// New registers and returns the selector's metrics with the default
+// Prometheus registry.
+func New() *Metrics {
+	return &Metrics{
+		hostsMatched: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_hosts_matched",
+			Help: "Number of hosts in the current whitelist.",
+		}),
+		minPriceSC: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_min_price_sc",
+			Help: "Minimum combined storage+upload+download price, in siacoins, among whitelisted hosts.",
+		}),
+		avgPriceSC: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_avg_price_sc",
+			Help: "Average combined storage+upload+download price, in siacoins, among whitelisted hosts.",
+		}),
+		avgUptime: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_avg_uptime",
+			Help: "Average estimated uptime percentage among whitelisted hosts.",
+		}),
+		avgDownloadBps: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_avg_download_bps",
+			Help: "Average benchmarked download speed, in bits per second, among whitelisted hosts.",
+		}),
+		lastUpdateTimestamp: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_last_update_timestamp",
+			Help: "Unix timestamp of the last successful whitelist update.",
+		}),
+		scUsdRate: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "selector_sc_usd_rate",
+			Help: "Siacoin/USD exchange rate used during the last successful whitelist update.",
+		}),
+		updateErrors: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "selector_update_errors_total",
+			Help: "Total number of whitelist update cycles that returned an error.",
+		}),
+	}
+}
+
+// Observe records the results of a successful whitelist update cycle.
+func (m *Metrics) Observe(hostsMatched int, minPriceSC, avgPriceSC, avgUptime, avgDownloadBps, scUsdRate float64) {
+	m.hostsMatched.Set(float64(hostsMatched))
+	m.minPriceSC.Set(minPriceSC)
+	m.avgPriceSC.Set(avgPriceSC)
+	m.avgUptime.Set(avgUptime)
+	m.avgDownloadBps.Set(avgDownloadBps)
+	m.scUsdRate.Set(scUsdRate)
+	m.lastUpdateTimestamp.SetToCurrentTime()
+}
+
+// IncUpdateErrors increments the update-error counter for a failed cycle.
+func (m *Metrics) IncUpdateErrors() {
+	m.updateErrors.Inc()
+}
+
+// Serve starts an HTTP server exposing /metrics on addr. It blocks until
+// the server returns an error, so callers should run it in a goroutine.
+func (m *Metrics) Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, mux)
+}
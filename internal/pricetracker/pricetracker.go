@@ -0,0 +1,223 @@
+// Package pricetracker records daily market pricing snapshots so the
+// selector can threshold on trailing percentiles instead of a static USD
+// ceiling that drifts as the SC/USD rate swings. Samples are persisted to
+// an embedded BoltDB so history survives restarts.
+package pricetracker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/siacentral/apisdkgo/sia"
+	"go.etcd.io/bbolt"
+	"go.sia.tech/siad/types"
+)
+
+var samplesBucket = []byte("samples")
+
+// Sample is a single daily snapshot of the market.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	// SCUSDRate is the Sia Central reported USD price of one siacoin.
+	SCUSDRate decimal.Decimal `json:"scUsdRate"`
+	// MedianStoragePrice, MedianUploadPrice, and MedianDownloadPrice are
+	// the median host prices across the active host set, in hastings
+	// per byte per block (storage) or per byte (upload/download).
+	MedianStoragePrice  types.Currency `json:"medianStoragePrice"`
+	MedianUploadPrice   types.Currency `json:"medianUploadPrice"`
+	MedianDownloadPrice types.Currency `json:"medianDownloadPrice"`
+}
+
+// Tracker persists Samples to an embedded BoltDB database.
+type Tracker struct {
+	db *bbolt.DB
+}
+
+// Open opens (creating if necessary) the price history database at path.
+func Open(path string) (*Tracker, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open price history db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(samplesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to init price history db: %w", err)
+	}
+	return &Tracker{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (t *Tracker) Close() error {
+	return t.db.Close()
+}
+
+func timestampKey(ts time.Time) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(ts.UnixNano()))
+	return buf
+}
+
+// Record persists a new sample, keyed by its timestamp.
+func (t *Tracker) Record(s Sample) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("unable to encode sample: %w", err)
+	}
+
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(samplesBucket).Put(timestampKey(s.Timestamp), buf)
+	})
+}
+
+// Since returns every sample recorded after cutoff, oldest first.
+func (t *Tracker) Since(cutoff time.Time) (samples []Sample, err error) {
+	err = t.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(samplesBucket).Cursor()
+		for k, v := c.Seek(timestampKey(cutoff)); k != nil; k, v = c.Next() {
+			var s Sample
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("unable to decode sample: %w", err)
+			}
+			samples = append(samples, s)
+		}
+		return nil
+	})
+	return
+}
+
+// All returns every recorded sample, oldest first.
+func (t *Tracker) All() (samples []Sample, err error) {
+	return t.Since(time.Unix(0, 0))
+}
+
+// currencyPercentile returns the value at the given percentile (0-100) of
+// a sorted slice of Currency, using big.Rat for the interpolation so the
+// result isn't subject to float rounding at the hastings precision
+// boundary.
+func currencyPercentile(sorted []types.Currency, pct float64) types.Currency {
+	if len(sorted) == 0 {
+		return types.ZeroCurrency
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := new(big.Rat).Mul(big.NewRat(int64(len(sorted)-1), 1), new(big.Rat).SetFloat64(pct/100))
+	lo := new(big.Int).Div(rank.Num(), rank.Denom())
+	loIdx := int(lo.Int64())
+	if loIdx >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	frac := new(big.Rat).Sub(rank, new(big.Rat).SetInt(lo))
+	lowVal := new(big.Rat).SetInt(sorted[loIdx].Big())
+	highVal := new(big.Rat).SetInt(sorted[loIdx+1].Big())
+
+	delta := new(big.Rat).Sub(highVal, lowVal)
+	delta.Mul(delta, frac)
+
+	result := new(big.Rat).Add(lowVal, delta)
+	quotient := new(big.Int).Quo(result.Num(), result.Denom())
+	return types.NewCurrency(quotient)
+}
+
+// Percentiles holds the trailing-window percentile prices used to
+// threshold the host filter.
+type Percentiles struct {
+	StoragePrice  types.Currency
+	UploadPrice   types.Currency
+	DownloadPrice types.Currency
+	NumSamples    int
+}
+
+// Percentile computes the pct-th percentile (e.g. 60 for the 60th) of
+// each price series over the trailing window days, using only samples
+// recorded within the window.
+func (t *Tracker) Percentile(window time.Duration, pct float64) (Percentiles, error) {
+	samples, err := t.Since(time.Now().Add(-window))
+	if err != nil {
+		return Percentiles{}, err
+	}
+	if len(samples) == 0 {
+		return Percentiles{}, nil
+	}
+
+	storage := make([]types.Currency, len(samples))
+	upload := make([]types.Currency, len(samples))
+	download := make([]types.Currency, len(samples))
+	for i, s := range samples {
+		storage[i] = s.MedianStoragePrice
+		upload[i] = s.MedianUploadPrice
+		download[i] = s.MedianDownloadPrice
+	}
+
+	sort.Slice(storage, func(i, j int) bool { return storage[i].Cmp(storage[j]) < 0 })
+	sort.Slice(upload, func(i, j int) bool { return upload[i].Cmp(upload[j]) < 0 })
+	sort.Slice(download, func(i, j int) bool { return download[i].Cmp(download[j]) < 0 })
+
+	return Percentiles{
+		StoragePrice:  currencyPercentile(storage, pct),
+		UploadPrice:   currencyPercentile(upload, pct),
+		DownloadPrice: currencyPercentile(download, pct),
+		NumSamples:    len(samples),
+	}, nil
+}
+
+// medianCurrency returns the median of a slice of Currency, copying and
+// sorting it first.
+func medianCurrency(values []types.Currency) types.Currency {
+	if len(values) == 0 {
+		return types.ZeroCurrency
+	}
+	sorted := make([]types.Currency, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	return currencyPercentile(sorted, 50)
+}
+
+// Poll queries Sia Central for the current SC/USD rate and the active
+// host set, and builds a Sample from the median of each host price. It
+// does not record the sample; callers should call Record with the
+// result.
+func Poll(client *sia.APIClient) (Sample, error) {
+	sc, _, err := client.GetExchangeRate()
+	if err != nil {
+		return Sample{}, fmt.Errorf("unable to get exchange rate: %w", err)
+	}
+	rate, ok := sc["usd"]
+	if !ok || rate <= 0 {
+		return Sample{}, fmt.Errorf("usd rate not found or 0")
+	}
+
+	hosts, err := client.GetActiveHosts(make(sia.HostFilter), 0, 500)
+	if err != nil {
+		return Sample{}, fmt.Errorf("unable to get active hosts: %w", err)
+	}
+
+	storage := make([]types.Currency, len(hosts))
+	upload := make([]types.Currency, len(hosts))
+	download := make([]types.Currency, len(hosts))
+	for i, host := range hosts {
+		storage[i] = host.Settings.StoragePrice
+		upload[i] = host.Settings.UploadBandwidthPrice
+		download[i] = host.Settings.DownloadBandwidthPrice
+	}
+
+	return Sample{
+		Timestamp:           time.Now(),
+		SCUSDRate:           decimal.NewFromFloat(rate),
+		MedianStoragePrice:  medianCurrency(storage),
+		MedianUploadPrice:   medianCurrency(upload),
+		MedianDownloadPrice: medianCurrency(download),
+	}, nil
+}
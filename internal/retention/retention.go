@@ -0,0 +1,101 @@
+// Package retention keeps previously-whitelisted hosts selected for a
+// few extra cycles when they fall just outside the top-K by score,
+// rather than churning them out over a marginal ranking change — a
+// renter pays to form a new contract with whatever replaces them.
+package retention
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/n8maninger/sia-host-selector/internal/scoring"
+)
+
+// Select takes diverse (the diversity-quota-selected set, already
+// sorted best-first) and ranked (the full scored pool diverse was drawn
+// from, also sorted best-first) and returns diverse plus any host from
+// ranked that isn't already in diverse, was in previous, and whose
+// composite score is within tolerance of diverse's own cutoff score.
+// This runs after diversity selection rather than before it, so a host
+// retained here is an explicit override of that cycle's diversity
+// quotas, not a candidate subject to them. A tolerance of 0 or an empty
+// diverse disables the extra retention and returns diverse unchanged.
+func Select(diverse []scoring.RankedHost, ranked []scoring.RankedHost, previous map[string]struct{}, tolerance float64) []scoring.RankedHost {
+	if tolerance <= 0 || len(diverse) == 0 || len(previous) == 0 {
+		return diverse
+	}
+
+	kept := make(map[string]struct{}, len(diverse))
+	for _, r := range diverse {
+		kept[r.Breakdown.PublicKey] = struct{}{}
+	}
+
+	selected := append(make([]scoring.RankedHost, 0, len(diverse)), diverse...)
+
+	// ranked is sorted descending by Composite, so once a host's score
+	// drops below the cutoff tolerance, no host after it can qualify
+	// either.
+	threshold := diverse[len(diverse)-1].Composite * (1 - tolerance)
+	for _, r := range ranked {
+		if r.Composite < threshold {
+			break
+		}
+		if _, ok := kept[r.Breakdown.PublicKey]; ok {
+			continue
+		}
+		if _, ok := previous[r.Breakdown.PublicKey]; ok {
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+// PublicKeys extracts the set of public keys from ranked, for persisting
+// as the next cycle's "previous whitelist" via Save.
+func PublicKeys(ranked []scoring.RankedHost) map[string]struct{} {
+	keys := make(map[string]struct{}, len(ranked))
+	for _, r := range ranked {
+		keys[r.Breakdown.PublicKey] = struct{}{}
+	}
+	return keys
+}
+
+// Load reads the public keys persisted by a previous Save call, one per
+// line. A missing file is treated as an empty set, since there's no
+// prior cycle to retain from on a cold start.
+func Load(path string) (map[string]struct{}, error) {
+	keys := make(map[string]struct{})
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return keys, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keys[scanner.Text()] = struct{}{}
+	}
+	return keys, scanner.Err()
+}
+
+// Save persists keys to path, one per line, overwriting any previous
+// contents.
+func Save(path string, keys map[string]struct{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create whitelist state file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for k := range keys {
+		if _, err := fmt.Fprintln(w, k); err != nil {
+			return fmt.Errorf("unable to write whitelist state file: %w", err)
+		}
+	}
+	return w.Flush()
+}
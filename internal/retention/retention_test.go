@@ -0,0 +1,112 @@
+package retention
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/siacentral/apisdkgo/sia"
+
+	"github.com/n8maninger/sia-host-selector/internal/scoring"
+)
+
+func rankedHost(publicKey string, composite float64) scoring.RankedHost {
+	return scoring.RankedHost{
+		Host:      sia.HostDetails{PublicKey: publicKey},
+		Breakdown: scoring.HostScoreBreakdown{PublicKey: publicKey},
+		Composite: composite,
+	}
+}
+
+func TestSelectNoTolerance(t *testing.T) {
+	ranked := []scoring.RankedHost{
+		rankedHost("a", 1.0),
+		rankedHost("b", 0.9),
+		rankedHost("c", 0.89),
+	}
+
+	diverse := ranked[:2]
+	got := Select(diverse, ranked, map[string]struct{}{"c": {}}, 0)
+	want := diverse
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelectRetainsWithinTolerance(t *testing.T) {
+	ranked := []scoring.RankedHost{
+		rankedHost("a", 1.0),
+		rankedHost("b", 0.9),
+		rankedHost("c", 0.89), // within 5% of the cutoff (0.9) and previously selected
+		rankedHost("d", 0.1),  // far below the cutoff
+	}
+
+	diverse := ranked[:2]
+	previous := map[string]struct{}{"c": {}, "d": {}}
+	got := Select(diverse, ranked, previous, 0.05)
+
+	want := append([]scoring.RankedHost{}, diverse...)
+	want = append(want, ranked[2])
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelectIgnoresHostsNotPreviouslySelected(t *testing.T) {
+	ranked := []scoring.RankedHost{
+		rankedHost("a", 1.0),
+		rankedHost("b", 0.9),
+		rankedHost("c", 0.89),
+	}
+
+	diverse := ranked[:2]
+	got := Select(diverse, ranked, map[string]struct{}{}, 0.05)
+	want := diverse
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelectTopKCoversEverything(t *testing.T) {
+	ranked := []scoring.RankedHost{rankedHost("a", 1.0), rankedHost("b", 0.9)}
+	got := Select(ranked, ranked, map[string]struct{}{"b": {}}, 0.5)
+	if !reflect.DeepEqual(got, ranked) {
+		t.Fatalf("got %v, want %v", got, ranked)
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	ranked := []scoring.RankedHost{rankedHost("a", 1.0), rankedHost("b", 0.9)}
+	got := PublicKeys(ranked)
+	want := map[string]struct{}{"a": {}, "b": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whitelist-state.txt")
+	keys := map[string]struct{}{"a": {}, "b": {}}
+
+	if err := Save(path, keys); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, keys) {
+		t.Fatalf("got %v, want %v", got, keys)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), "missing.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected an empty set, got %v", got)
+	}
+}
@@ -0,0 +1,246 @@
+// Package scoring ranks hosts that have already passed the selector's
+// hard filters (accepting contracts, min age, gouging caps) by a
+// composite score built from normalized, user-weighted sub-scores. It
+// replaces sorting the filtered host set by download speed alone.
+package scoring
+
+import (
+	"sort"
+	"time"
+
+	"github.com/siacentral/apisdkgo/sia"
+
+	"github.com/n8maninger/sia-host-selector/internal/config"
+)
+
+// HostScoreBreakdown holds a host's normalized (0-1) sub-scores, so the
+// selector can explain why a host ranked where it did. Higher is always
+// better, including for Price, which is inverted during normalization.
+type HostScoreBreakdown struct {
+	PublicKey        string
+	Age              float64
+	Uptime           float64
+	Collateral       float64
+	StorageRemaining float64
+	DownloadSpeed    float64
+	UploadSpeed      float64
+	Price            float64
+	// LocalBenchmark is the normalized connect latency measured directly
+	// by the benchmark package, from this renter's vantage point (lower
+	// latency scores higher). It's 0 for hosts that weren't locally
+	// benchmarked.
+	LocalBenchmark float64
+}
+
+// Composite combines a breakdown's sub-scores into a single score using
+// w as the per-criterion weights. A criterion with weight 0 does not
+// contribute.
+func (b HostScoreBreakdown) Composite(w config.Weights) float64 {
+	totalWeight := w.Age + w.Uptime + w.Collateral + w.StorageRemaining + w.DownloadSpeed + w.UploadSpeed + w.Price + w.LocalBenchmark
+	if totalWeight <= 0 {
+		return 0
+	}
+
+	sum := b.Age*w.Age +
+		b.Uptime*w.Uptime +
+		b.Collateral*w.Collateral +
+		b.StorageRemaining*w.StorageRemaining +
+		b.DownloadSpeed*w.DownloadSpeed +
+		b.UploadSpeed*w.UploadSpeed +
+		b.Price*w.Price +
+		b.LocalBenchmark*w.LocalBenchmark
+	return sum / totalWeight
+}
+
+// RankedHost pairs a host with its score breakdown and resulting
+// composite, in the order it was ranked.
+type RankedHost struct {
+	Host      sia.HostDetails
+	Breakdown HostScoreBreakdown
+	Composite float64
+}
+
+// rawMetrics is the set of un-normalized values scoring needs per host,
+// extracted once up front.
+type rawMetrics struct {
+	age              time.Duration
+	uptime           float64
+	collateral       float64
+	storageRemaining float64
+	downloadSpeed    float64
+	uploadSpeed      float64
+	// price is the combined storage+upload+download cost; lower is
+	// better so it's inverted during normalization.
+	price float64
+}
+
+func extractMetrics(host sia.HostDetails) rawMetrics {
+	upSeconds := float64(host.Benchmark.UploadTime) / 1000
+	downSeconds := float64(host.Benchmark.DownloadTime) / 1000
+	dataBits := float64(host.Benchmark.DataSize) * 8
+
+	var upBps, downBps float64
+	if upSeconds > 0 {
+		upBps = dataBits / upSeconds
+	}
+	if downSeconds > 0 {
+		downBps = dataBits / downSeconds
+	}
+
+	storage, _ := host.Settings.StoragePrice.Float64()
+	upload, _ := host.Settings.UploadBandwidthPrice.Float64()
+	download, _ := host.Settings.DownloadBandwidthPrice.Float64()
+	collateral, _ := host.Settings.MaxCollateral.Float64()
+	remaining := float64(host.Settings.RemainingStorage)
+
+	return rawMetrics{
+		age:              time.Since(host.FirstSeenTimestamp),
+		uptime:           float64(host.EstimatedUptime),
+		collateral:       collateral,
+		storageRemaining: remaining,
+		downloadSpeed:    downBps,
+		uploadSpeed:      upBps,
+		price:            storage + upload + download,
+	}
+}
+
+// normalize min-max scales v into [0, 1] given the set's min and max. A
+// degenerate range (min == max) scores every host 1, since there's
+// nothing to differentiate them on that criterion.
+func normalize(v, min, max float64) float64 {
+	if max <= min {
+		return 1
+	}
+	return (v - min) / (max - min)
+}
+
+// Rank scores hosts against w and returns them sorted by descending
+// composite score, truncated to the top topK. Each sub-score is
+// normalized relative to the rest of the input set, so scores are only
+// meaningful within a single call to Rank. localBenchmark optionally maps
+// a host's public key to a locally-measured score where higher is
+// better (typically an inverse connect latency); hosts absent from the
+// map score 0 on that criterion.
+func Rank(hosts []sia.HostDetails, w config.Weights, topK int, localBenchmark map[string]float64) []RankedHost {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	metrics := make([]rawMetrics, len(hosts))
+	for i, host := range hosts {
+		metrics[i] = extractMetrics(host)
+	}
+
+	var minLocal, maxLocal float64
+	for i, host := range hosts {
+		v := localBenchmark[host.PublicKey]
+		if i == 0 {
+			minLocal, maxLocal = v, v
+			continue
+		}
+		if v < minLocal {
+			minLocal = v
+		}
+		if v > maxLocal {
+			maxLocal = v
+		}
+	}
+
+	var minAge, maxAge time.Duration
+	var minUptime, maxUptime float64
+	var minCollateral, maxCollateral float64
+	var minRemaining, maxRemaining float64
+	var minDown, maxDown float64
+	var minUp, maxUp float64
+	var minPrice, maxPrice float64
+
+	for i, m := range metrics {
+		if i == 0 {
+			minAge, maxAge = m.age, m.age
+			minUptime, maxUptime = m.uptime, m.uptime
+			minCollateral, maxCollateral = m.collateral, m.collateral
+			minRemaining, maxRemaining = m.storageRemaining, m.storageRemaining
+			minDown, maxDown = m.downloadSpeed, m.downloadSpeed
+			minUp, maxUp = m.uploadSpeed, m.uploadSpeed
+			minPrice, maxPrice = m.price, m.price
+			continue
+		}
+
+		if m.age < minAge {
+			minAge = m.age
+		}
+		if m.age > maxAge {
+			maxAge = m.age
+		}
+		if m.uptime < minUptime {
+			minUptime = m.uptime
+		}
+		if m.uptime > maxUptime {
+			maxUptime = m.uptime
+		}
+		if m.collateral < minCollateral {
+			minCollateral = m.collateral
+		}
+		if m.collateral > maxCollateral {
+			maxCollateral = m.collateral
+		}
+		if m.storageRemaining < minRemaining {
+			minRemaining = m.storageRemaining
+		}
+		if m.storageRemaining > maxRemaining {
+			maxRemaining = m.storageRemaining
+		}
+		if m.downloadSpeed < minDown {
+			minDown = m.downloadSpeed
+		}
+		if m.downloadSpeed > maxDown {
+			maxDown = m.downloadSpeed
+		}
+		if m.uploadSpeed < minUp {
+			minUp = m.uploadSpeed
+		}
+		if m.uploadSpeed > maxUp {
+			maxUp = m.uploadSpeed
+		}
+		if m.price < minPrice {
+			minPrice = m.price
+		}
+		if m.price > maxPrice {
+			maxPrice = m.price
+		}
+	}
+
+	ranked := make([]RankedHost, len(hosts))
+	for i, host := range hosts {
+		m := metrics[i]
+		var local float64
+		if v, ok := localBenchmark[host.PublicKey]; ok {
+			local = normalize(v, minLocal, maxLocal)
+		}
+		breakdown := HostScoreBreakdown{
+			PublicKey:        host.PublicKey,
+			Age:              normalize(float64(m.age), float64(minAge), float64(maxAge)),
+			Uptime:           normalize(m.uptime, minUptime, maxUptime),
+			Collateral:       normalize(m.collateral, minCollateral, maxCollateral),
+			StorageRemaining: normalize(m.storageRemaining, minRemaining, maxRemaining),
+			DownloadSpeed:    normalize(m.downloadSpeed, minDown, maxDown),
+			UploadSpeed:      normalize(m.uploadSpeed, minUp, maxUp),
+			// price is inverted: the cheapest host should score 1.
+			Price:          1 - normalize(m.price, minPrice, maxPrice),
+			LocalBenchmark: local,
+		}
+
+		ranked[i] = RankedHost{
+			Host:      host,
+			Breakdown: breakdown,
+			Composite: breakdown.Composite(w),
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].Composite > ranked[j].Composite })
+
+	if topK > 0 && len(ranked) > topK {
+		ranked = ranked[:topK]
+	}
+	return ranked
+}